@@ -0,0 +1,75 @@
+// Package analyzers defines the pluggable processing stage that sits between the
+// stumbler module's single IMU read loop and whatever wants to do something with the
+// data: report it, detect a stumble in it, or just remember it for later.
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// Sample is a single raw IMU reading, as pulled from the sensor by the module's read loop.
+type Sample struct {
+	Time        time.Time
+	Accel       r3.Vector
+	Gyro        r3.Vector
+	Orientation spatialmath.Orientation
+}
+
+// Analyzer is a pluggable consumer of the sample stream. Implementations should not
+// block Start for longer than it takes to spin up their own goroutine(s): the group
+// that owns them fans samples out to every analyzer concurrently, and a slow Start
+// would delay the others.
+type Analyzer interface {
+	// Name returns this analyzer instance's configured name, used to route DoCommand calls.
+	Name() string
+	// Start begins consuming from samples. It must return promptly; any ongoing work
+	// should happen in a goroutine that exits when samples is closed or ctx is done.
+	Start(ctx context.Context, samples <-chan Sample) error
+	// Stop signals the analyzer to wind down and blocks until it has.
+	Stop(ctx context.Context) error
+	// DoCommand handles an analyzer-specific command, analogous to resource.DoCommand.
+	DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Constructor builds one Analyzer instance from its configured name and free-form
+// attributes (decoded per-type via decodeAttributes).
+type Constructor func(name string, attributes map[string]interface{}, logger golog.Logger) (Analyzer, error)
+
+var registry = map[string]Constructor{}
+
+// Register makes a built-in analyzer type available to InstanceConfig.Type. It is meant
+// to be called from an init() in the file that implements the type, mirroring how this
+// module's resource models register themselves.
+func Register(typeName string, constructor Constructor) {
+	registry[typeName] = constructor
+}
+
+// New constructs the analyzer registered under typeName.
+func New(typeName, name string, attributes map[string]interface{}, logger golog.Logger) (Analyzer, error) {
+	constructor, ok := registry[typeName]
+	if !ok {
+		return nil, errors.Errorf("unknown analyzer type %q", typeName)
+	}
+	return constructor(name, attributes, logger)
+}
+
+// decodeAttributes round-trips a free-form attributes map into a typed config struct via
+// JSON, the same shape Attributes arrives in off the wire.
+func decodeAttributes(attributes map[string]interface{}, into interface{}) error {
+	if attributes == nil {
+		return nil
+	}
+	raw, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, into)
+}
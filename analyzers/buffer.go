@@ -0,0 +1,281 @@
+package analyzers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("ring_buffer", newRingBufferAnalyzer)
+}
+
+// BufferConfig configures the ring_buffer analyzer.
+type BufferConfig struct {
+	// Size bounds the in-memory ring buffer of raw samples.
+	Size int `json:"size"`
+}
+
+const defaultBufferSize = 2000
+
+func (c BufferConfig) size() int {
+	if c.Size <= 0 {
+		return defaultBufferSize
+	}
+	return c.Size
+}
+
+// RecordedSample is a JSON-friendly copy of a Sample, as returned by the snapshot command.
+type RecordedSample struct {
+	Time        time.Time  `json:"time"`
+	Accel       r3.Vector  `json:"accel"`
+	Gyro        r3.Vector  `json:"gyro"`
+	Orientation EulerAngle `json:"orientation"`
+}
+
+// EulerAngle is a JSON-friendly copy of the pitch/roll/yaw a spatialmath.Orientation exposes.
+type EulerAngle struct {
+	Pitch float64 `json:"pitch"`
+	Roll  float64 `json:"roll"`
+	Yaw   float64 `json:"yaw"`
+}
+
+// axisStats summarizes one axis (mean/variance/min/max) over a set of samples.
+type axisStats struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+}
+
+func computeAxisStats(values []float64) axisStats {
+	if len(values) == 0 {
+		return axisStats{}
+	}
+	stats := axisStats{Min: values[0], Max: values[0]}
+	var sum float64
+	for _, v := range values {
+		sum += v
+		if v < stats.Min {
+			stats.Min = v
+		}
+		if v > stats.Max {
+			stats.Max = v
+		}
+	}
+	stats.Mean = sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		d := v - stats.Mean
+		variance += d * d
+	}
+	stats.Variance = variance / float64(len(values))
+	return stats
+}
+
+// bufferStats summarizes the current fill level, effective sample rate, and
+// per-axis accel/gyro stats of a ring buffer.
+type bufferStats struct {
+	FillLevel int       `json:"fill_level"`
+	Capacity  int       `json:"capacity"`
+	SampleHz  float64   `json:"sample_hz"`
+	AccelX    axisStats `json:"accel_x"`
+	AccelY    axisStats `json:"accel_y"`
+	AccelZ    axisStats `json:"accel_z"`
+	GyroX     axisStats `json:"gyro_x"`
+	GyroY     axisStats `json:"gyro_y"`
+	GyroZ     axisStats `json:"gyro_z"`
+}
+
+// ringBufferAnalyzer records every sample it sees into a fixed-size circular buffer,
+// safe for concurrent use by the producer goroutine and DoCommand handlers. This lets
+// operators pull a short pre-incident trace without streaming everything to the cloud.
+type ringBufferAnalyzer struct {
+	name   string
+	logger golog.Logger
+
+	mu      sync.Mutex
+	samples []RecordedSample
+	size    int
+	next    int
+	full    bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newRingBufferAnalyzer(name string, attributes map[string]interface{}, logger golog.Logger) (Analyzer, error) {
+	var cfg BufferConfig
+	if err := decodeAttributes(attributes, &cfg); err != nil {
+		return nil, err
+	}
+	size := cfg.size()
+	return &ringBufferAnalyzer{
+		name:    name,
+		logger:  logger,
+		samples: make([]RecordedSample, size),
+		size:    size,
+	}, nil
+}
+
+func (b *ringBufferAnalyzer) Name() string {
+	return b.name
+}
+
+func (b *ringBufferAnalyzer) Start(ctx context.Context, samples <-chan Sample) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case s, ok := <-samples:
+				if !ok {
+					return
+				}
+				ea := s.Orientation.EulerAngles()
+				b.add(RecordedSample{
+					Time:  s.Time,
+					Accel: s.Accel,
+					Gyro:  s.Gyro,
+					Orientation: EulerAngle{
+						Pitch: ea.Pitch,
+						Roll:  ea.Roll,
+						Yaw:   ea.Yaw,
+					},
+				})
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *ringBufferAnalyzer) Stop(ctx context.Context) error {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.wg.Wait()
+	return nil
+}
+
+func (b *ringBufferAnalyzer) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	switch cmd["command"] {
+	case "snapshot":
+		lastMs, _ := cmd["last_ms"].(float64)
+		return map[string]interface{}{"samples": b.snapshot(int(lastMs))}, nil
+	case "stats":
+		return map[string]interface{}{"stats": b.stats()}, nil
+	default:
+		return nil, errors.Errorf("unknown command string %v", cmd["command"])
+	}
+}
+
+// Reconfigure lets the analyzers.Group resize this buffer in place instead of
+// stopping and restarting the analyzer, preserving as many of the most recent
+// samples as fit in the new size.
+func (b *ringBufferAnalyzer) Reconfigure(attributes map[string]interface{}) error {
+	var cfg BufferConfig
+	if err := decodeAttributes(attributes, &cfg); err != nil {
+		return err
+	}
+	b.resize(cfg.size())
+	return nil
+}
+
+// resize grows or shrinks the buffer in place, keeping as many of the most recent
+// samples as fit in the new size.
+func (b *ringBufferAnalyzer) resize(size int) {
+	ordered := b.ordered()
+	if len(ordered) > size {
+		ordered = ordered[len(ordered)-size:]
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.samples = make([]RecordedSample, size)
+	b.size = size
+	copy(b.samples, ordered)
+	b.next = len(ordered) % size
+	b.full = len(ordered) == size
+}
+
+func (b *ringBufferAnalyzer) add(s RecordedSample) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.samples[b.next] = s
+	b.next = (b.next + 1) % b.size
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// ordered returns the buffered samples in chronological order, oldest first.
+func (b *ringBufferAnalyzer) ordered() []RecordedSample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		out := make([]RecordedSample, b.next)
+		copy(out, b.samples[:b.next])
+		return out
+	}
+	out := make([]RecordedSample, b.size)
+	copy(out, b.samples[b.next:])
+	copy(out[b.size-b.next:], b.samples[:b.next])
+	return out
+}
+
+// snapshot returns the samples from the last lastMs milliseconds, oldest first.
+func (b *ringBufferAnalyzer) snapshot(lastMs int) []RecordedSample {
+	all := b.ordered()
+	if lastMs <= 0 {
+		return all
+	}
+	cutoff := time.Now().Add(-time.Duration(lastMs) * time.Millisecond)
+	for i, s := range all {
+		if s.Time.After(cutoff) {
+			return all[i:]
+		}
+	}
+	return nil
+}
+
+func (b *ringBufferAnalyzer) stats() bufferStats {
+	samples := b.ordered()
+
+	b.mu.Lock()
+	capacity := b.size
+	b.mu.Unlock()
+
+	stats := bufferStats{FillLevel: len(samples), Capacity: capacity}
+	if len(samples) == 0 {
+		return stats
+	}
+	if span := samples[len(samples)-1].Time.Sub(samples[0].Time); span > 0 {
+		stats.SampleHz = float64(len(samples)-1) / span.Seconds()
+	}
+
+	ax := make([]float64, len(samples))
+	ay := make([]float64, len(samples))
+	az := make([]float64, len(samples))
+	gx := make([]float64, len(samples))
+	gy := make([]float64, len(samples))
+	gz := make([]float64, len(samples))
+	for i, s := range samples {
+		ax[i], ay[i], az[i] = s.Accel.X, s.Accel.Y, s.Accel.Z
+		gx[i], gy[i], gz[i] = s.Gyro.X, s.Gyro.Y, s.Gyro.Z
+	}
+	stats.AccelX = computeAxisStats(ax)
+	stats.AccelY = computeAxisStats(ay)
+	stats.AccelZ = computeAxisStats(az)
+	stats.GyroX = computeAxisStats(gx)
+	stats.GyroY = computeAxisStats(gy)
+	stats.GyroZ = computeAxisStats(gz)
+	return stats
+}
@@ -0,0 +1,131 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r3"
+)
+
+func newTestRingBufferAnalyzer(size int) *ringBufferAnalyzer {
+	return &ringBufferAnalyzer{
+		name:    "test",
+		samples: make([]RecordedSample, size),
+		size:    size,
+	}
+}
+
+func recordedSampleAt(t time.Time, x float64) RecordedSample {
+	return RecordedSample{Time: t, Accel: r3.Vector{X: x}}
+}
+
+func TestRingBufferOrderedBeforeFull(t *testing.T) {
+	b := newTestRingBufferAnalyzer(4)
+	base := time.Unix(0, 0)
+
+	b.add(recordedSampleAt(base, 1))
+	b.add(recordedSampleAt(base.Add(time.Second), 2))
+
+	ordered := b.ordered()
+	if len(ordered) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(ordered))
+	}
+	if ordered[0].Accel.X != 1 || ordered[1].Accel.X != 2 {
+		t.Fatalf("expected samples in insertion order, got %+v", ordered)
+	}
+}
+
+func TestRingBufferWrapsAndDropsOldest(t *testing.T) {
+	b := newTestRingBufferAnalyzer(3)
+	base := time.Unix(0, 0)
+
+	for i := 0; i < 5; i++ {
+		b.add(recordedSampleAt(base.Add(time.Duration(i)*time.Second), float64(i)))
+	}
+
+	ordered := b.ordered()
+	if len(ordered) != 3 {
+		t.Fatalf("expected buffer capped at 3 samples, got %d", len(ordered))
+	}
+	// The oldest two (0, 1) should have been overwritten; only 2, 3, 4 remain, oldest first.
+	for i, want := range []float64{2, 3, 4} {
+		if ordered[i].Accel.X != want {
+			t.Errorf("ordered[%d] = %v, want %v", i, ordered[i].Accel.X, want)
+		}
+	}
+}
+
+func TestRingBufferResizeShrinkKeepsMostRecent(t *testing.T) {
+	b := newTestRingBufferAnalyzer(5)
+	base := time.Unix(0, 0)
+
+	for i := 0; i < 5; i++ {
+		b.add(recordedSampleAt(base.Add(time.Duration(i)*time.Second), float64(i)))
+	}
+
+	b.resize(2)
+
+	ordered := b.ordered()
+	if len(ordered) != 2 {
+		t.Fatalf("expected 2 samples after shrinking to size 2, got %d", len(ordered))
+	}
+	if ordered[0].Accel.X != 3 || ordered[1].Accel.X != 4 {
+		t.Fatalf("expected the 2 most recent samples preserved, got %+v", ordered)
+	}
+}
+
+func TestRingBufferResizeGrowKeepsAllSamples(t *testing.T) {
+	b := newTestRingBufferAnalyzer(2)
+	base := time.Unix(0, 0)
+
+	b.add(recordedSampleAt(base, 1))
+	b.add(recordedSampleAt(base.Add(time.Second), 2))
+
+	b.resize(5)
+	b.add(recordedSampleAt(base.Add(2*time.Second), 3))
+
+	ordered := b.ordered()
+	if len(ordered) != 3 {
+		t.Fatalf("expected 3 samples after growing and adding one more, got %d", len(ordered))
+	}
+	for i, want := range []float64{1, 2, 3} {
+		if ordered[i].Accel.X != want {
+			t.Errorf("ordered[%d] = %v, want %v", i, ordered[i].Accel.X, want)
+		}
+	}
+}
+
+func TestComputeAxisStats(t *testing.T) {
+	stats := computeAxisStats([]float64{1, 2, 3, 4})
+	if stats.Mean != 2.5 {
+		t.Errorf("expected mean 2.5, got %v", stats.Mean)
+	}
+	if stats.Min != 1 || stats.Max != 4 {
+		t.Errorf("expected min 1 / max 4, got min %v max %v", stats.Min, stats.Max)
+	}
+	if stats.Variance != 1.25 {
+		t.Errorf("expected variance 1.25, got %v", stats.Variance)
+	}
+}
+
+func TestBufferStatsFillLevelAndCapacity(t *testing.T) {
+	b := newTestRingBufferAnalyzer(3)
+	base := time.Unix(0, 0)
+
+	b.add(recordedSampleAt(base, 1))
+	b.add(recordedSampleAt(base.Add(time.Second), 3))
+
+	stats := b.stats()
+	if stats.FillLevel != 2 {
+		t.Errorf("expected fill level 2, got %d", stats.FillLevel)
+	}
+	if stats.Capacity != 3 {
+		t.Errorf("expected capacity 3, got %d", stats.Capacity)
+	}
+	if stats.AccelX.Mean != 2 {
+		t.Errorf("expected accel_x mean 2, got %v", stats.AccelX.Mean)
+	}
+	if stats.SampleHz != 1 {
+		t.Errorf("expected sample_hz 1 from a 1s gap, got %v", stats.SampleHz)
+	}
+}
@@ -0,0 +1,229 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+)
+
+// defaultBufferDepth is how many samples an analyzer's channel holds before the drop
+// policy kicks in, used when an InstanceConfig doesn't specify one.
+const defaultBufferDepth = 32
+
+// InstanceConfig selects one enabled analyzer instance and its per-instance parameters.
+type InstanceConfig struct {
+	// Name identifies this instance; DoCommand routes to it via the "analyzer" key.
+	Name string `json:"name"`
+	// Type is the built-in analyzer type to construct, e.g. "moving_average", "stumble", "ring_buffer".
+	Type string `json:"type"`
+	// BufferDepth is how many samples this instance's channel can hold before the drop policy applies.
+	BufferDepth int `json:"buffer_depth"`
+	// DropOldest makes a full channel evict its oldest unread sample instead of dropping the new one.
+	DropOldest bool `json:"drop_oldest"`
+	// Attributes are type-specific parameters, decoded by the analyzer's constructor.
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func (c InstanceConfig) bufferDepth() int {
+	if c.BufferDepth <= 0 {
+		return defaultBufferDepth
+	}
+	return c.BufferDepth
+}
+
+// fingerprint captures everything about an InstanceConfig, Attributes included;
+// comparing fingerprints is how Reconfigure decides what to leave alone.
+func (c InstanceConfig) fingerprint() string {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return c.Name
+	}
+	return string(raw)
+}
+
+// shellFingerprint captures everything about an InstanceConfig EXCEPT Attributes: the
+// channel/lifecycle shape that can't change without a restart. Two configs with the same
+// shellFingerprint but different Attributes can potentially be reconciled in place via
+// Reconfigurable, instead of a full stop/start.
+func (c InstanceConfig) shellFingerprint() string {
+	c.Attributes = nil
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return c.Name
+	}
+	return string(raw)
+}
+
+// Reconfigurable is implemented by analyzers that can absorb an Attributes change in
+// place instead of being stopped and restarted, e.g. to resize a buffer without losing
+// its contents. Group prefers this over a full restart whenever only Attributes differ.
+type Reconfigurable interface {
+	Reconfigure(attributes map[string]interface{}) error
+}
+
+type groupMember struct {
+	analyzer    Analyzer
+	cfg         InstanceConfig
+	fingerprint string
+	samples     chan Sample
+	cancel      context.CancelFunc
+}
+
+// Group owns the set of running analyzers, fans a single sample stream out to each of
+// them over its own buffered channel, and diffs InstanceConfigs across Reconfigure calls
+// so unaffected analyzers keep running undisturbed.
+type Group struct {
+	logger golog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	members map[string]*groupMember
+}
+
+// NewGroup creates an empty Group. Its internal context lives until StopAll is called,
+// independent of any single Reconfigure.
+func NewGroup(logger golog.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		members: map[string]*groupMember{},
+	}
+}
+
+// Reconfigure starts analyzers newly present in cfgs, stops ones no longer present,
+// applies attribute-only changes in place for Reconfigurable analyzers, restarts ones
+// whose configuration otherwise changed, and leaves everything else running untouched.
+func (g *Group) Reconfigure(ctx context.Context, cfgs []InstanceConfig) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	wanted := make(map[string]InstanceConfig, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, dup := wanted[cfg.Name]; dup {
+			return errors.Errorf("duplicate analyzer name %q", cfg.Name)
+		}
+		wanted[cfg.Name] = cfg
+	}
+
+	for name, member := range g.members {
+		cfg, stillWanted := wanted[name]
+		if !stillWanted {
+			g.stopMemberLocked(ctx, member)
+			delete(g.members, name)
+			continue
+		}
+		if cfg.fingerprint() == member.fingerprint {
+			continue
+		}
+		if cfg.shellFingerprint() == member.cfg.shellFingerprint() {
+			if reconfigurable, ok := member.analyzer.(Reconfigurable); ok {
+				if err := reconfigurable.Reconfigure(cfg.Attributes); err == nil {
+					member.cfg = cfg
+					member.fingerprint = cfg.fingerprint()
+					continue
+				}
+				g.logger.Errorw("analyzer failed in-place reconfigure, restarting it", "name", name)
+			}
+		}
+		g.stopMemberLocked(ctx, member)
+		delete(g.members, name)
+	}
+
+	for name, cfg := range wanted {
+		if _, ok := g.members[name]; ok {
+			continue
+		}
+		member, err := g.startMemberLocked(cfg)
+		if err != nil {
+			return errors.Wrapf(err, "starting analyzer %q", name)
+		}
+		g.members[name] = member
+	}
+
+	return nil
+}
+
+func (g *Group) startMemberLocked(cfg InstanceConfig) (*groupMember, error) {
+	analyzer, err := New(cfg.Type, cfg.Name, cfg.Attributes, g.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	memberCtx, cancel := context.WithCancel(g.ctx)
+	samples := make(chan Sample, cfg.bufferDepth())
+	if err := analyzer.Start(memberCtx, samples); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &groupMember{
+		analyzer:    analyzer,
+		cfg:         cfg,
+		fingerprint: cfg.fingerprint(),
+		samples:     samples,
+		cancel:      cancel,
+	}, nil
+}
+
+func (g *Group) stopMemberLocked(ctx context.Context, member *groupMember) {
+	member.cancel()
+	if err := member.analyzer.Stop(ctx); err != nil {
+		g.logger.Errorw("error stopping analyzer", "name", member.cfg.Name, "error", err)
+	}
+	close(member.samples)
+}
+
+// Dispatch fans one sample out to every running analyzer. Each analyzer's channel send
+// is non-blocking: a full channel either drops the new sample (default) or, if that
+// analyzer's DropOldest is set, evicts the oldest buffered sample to make room.
+func (g *Group) Dispatch(s Sample) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, member := range g.members {
+		select {
+		case member.samples <- s:
+		default:
+			if !member.cfg.DropOldest {
+				continue
+			}
+			select {
+			case <-member.samples:
+			default:
+			}
+			select {
+			case member.samples <- s:
+			default:
+			}
+		}
+	}
+}
+
+// DoCommand routes to the named analyzer instance.
+func (g *Group) DoCommand(ctx context.Context, name string, cmd map[string]interface{}) (map[string]interface{}, error) {
+	g.mu.Lock()
+	member, ok := g.members[name]
+	g.mu.Unlock()
+	if !ok {
+		return nil, errors.Errorf("no analyzer named %q", name)
+	}
+	return member.analyzer.DoCommand(ctx, cmd)
+}
+
+// StopAll stops every running analyzer and tears down the Group itself.
+func (g *Group) StopAll(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for name, member := range g.members {
+		g.stopMemberLocked(ctx, member)
+		delete(g.members, name)
+	}
+	g.cancel()
+	return nil
+}
@@ -0,0 +1,120 @@
+package analyzers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+
+	"github.com/RobinUS2/golang-moving-average"
+)
+
+func init() {
+	Register("moving_average", newReporter)
+}
+
+// ReporterConfig configures the moving_average analyzer.
+type ReporterConfig struct {
+	// WindowSize is how many samples each axis' moving average covers.
+	WindowSize int `json:"window_size"`
+}
+
+const defaultReporterWindowSize = 50
+
+func (c ReporterConfig) windowSize() int {
+	if c.WindowSize <= 0 {
+		return defaultReporterWindowSize
+	}
+	return c.WindowSize
+}
+
+// reporter is the original "accumulate moving averages" behavior, now just one analyzer
+// among several instead of the whole read loop.
+type reporter struct {
+	name   string
+	logger golog.Logger
+	cfg    ReporterConfig
+
+	mu                     sync.Mutex
+	accelX, accelY, accelZ *movingaverage.MovingAverage
+	gyroX, gyroY, gyroZ    *movingaverage.MovingAverage
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newReporter(name string, attributes map[string]interface{}, logger golog.Logger) (Analyzer, error) {
+	var cfg ReporterConfig
+	if err := decodeAttributes(attributes, &cfg); err != nil {
+		return nil, err
+	}
+	window := cfg.windowSize()
+	return &reporter{
+		name:   name,
+		logger: logger,
+		cfg:    cfg,
+		accelX: movingaverage.New(window),
+		accelY: movingaverage.New(window),
+		accelZ: movingaverage.New(window),
+		gyroX:  movingaverage.New(window),
+		gyroY:  movingaverage.New(window),
+		gyroZ:  movingaverage.New(window),
+	}, nil
+}
+
+func (r *reporter) Name() string {
+	return r.name
+}
+
+func (r *reporter) Start(ctx context.Context, samples <-chan Sample) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case s, ok := <-samples:
+				if !ok {
+					return
+				}
+				r.mu.Lock()
+				r.accelX.Add(s.Accel.X)
+				r.accelY.Add(s.Accel.Y)
+				r.accelZ.Add(s.Accel.Z)
+				r.gyroX.Add(s.Gyro.X)
+				r.gyroY.Add(s.Gyro.Y)
+				r.gyroZ.Add(s.Gyro.Z)
+				r.mu.Unlock()
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *reporter) Stop(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	return nil
+}
+
+func (r *reporter) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if cmd["command"] != "get" {
+		return nil, errors.Errorf("unknown command string %v", cmd["command"])
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return map[string]interface{}{
+		"accel_x": r.accelX.Avg(),
+		"accel_y": r.accelY.Avg(),
+		"accel_z": r.accelZ.Avg(),
+		"gyro_x":  r.gyroX.Avg(),
+		"gyro_y":  r.gyroY.Avg(),
+		"gyro_z":  r.gyroZ.Avg(),
+	}, nil
+}
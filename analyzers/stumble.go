@@ -0,0 +1,315 @@
+package analyzers
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edaniels/golog"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("stumble", newStumbleAnalyzer)
+}
+
+// standardGravity converts an SI acceleration (m/s^2) into g's.
+const standardGravity = 9.80665
+
+// StumbleConfig configures the stumble analyzer's free-fall/impact/orientation-change
+// state machine; all fields have sane defaults when left at zero.
+type StumbleConfig struct {
+	// FreeFallThresholdG is the SVM (in g) below which we consider the sensor to be in free-fall.
+	FreeFallThresholdG float64 `json:"free_fall_threshold_g"`
+	// FreeFallMinMs is how long the SVM must stay below FreeFallThresholdG to count as free-fall.
+	FreeFallMinMs int `json:"free_fall_min_ms"`
+	// ImpactThresholdG is the SVM (in g) above which we consider an impact to have occurred.
+	ImpactThresholdG float64 `json:"impact_threshold_g"`
+	// ImpactWindowMs bounds how long after free-fall ends we'll wait for an impact peak.
+	ImpactWindowMs int `json:"impact_window_ms"`
+	// OrientationChangeDeg is the minimum pitch/roll change after impact required to confirm a stumble.
+	OrientationChangeDeg float64 `json:"orientation_change_deg"`
+	// PostImpactWindowMs bounds how long after impact we watch for the orientation change.
+	PostImpactWindowMs int `json:"post_impact_window_ms"`
+	// CooldownMs is the minimum time between two reported events.
+	CooldownMs int `json:"cooldown_ms"`
+	// MaxEvents bounds the in-memory ring buffer of stumble events.
+	MaxEvents int `json:"max_events"`
+}
+
+const (
+	defaultFreeFallThresholdG   = 0.4
+	defaultFreeFallMinMs        = 50
+	defaultImpactThresholdG     = 2.0
+	defaultImpactWindowMs       = 400
+	defaultOrientationChangeDeg = 30
+	defaultPostImpactWindowMs   = 500
+	defaultCooldownMs           = 1000
+	defaultMaxEvents            = 100
+)
+
+func (c StumbleConfig) freeFallThresholdG() float64 {
+	if c.FreeFallThresholdG <= 0 {
+		return defaultFreeFallThresholdG
+	}
+	return c.FreeFallThresholdG
+}
+
+func (c StumbleConfig) freeFallMin() time.Duration {
+	if c.FreeFallMinMs <= 0 {
+		return defaultFreeFallMinMs * time.Millisecond
+	}
+	return time.Duration(c.FreeFallMinMs) * time.Millisecond
+}
+
+func (c StumbleConfig) impactThresholdG() float64 {
+	if c.ImpactThresholdG <= 0 {
+		return defaultImpactThresholdG
+	}
+	return c.ImpactThresholdG
+}
+
+func (c StumbleConfig) impactWindow() time.Duration {
+	if c.ImpactWindowMs <= 0 {
+		return defaultImpactWindowMs * time.Millisecond
+	}
+	return time.Duration(c.ImpactWindowMs) * time.Millisecond
+}
+
+func (c StumbleConfig) orientationChangeDeg() float64 {
+	if c.OrientationChangeDeg <= 0 {
+		return defaultOrientationChangeDeg
+	}
+	return c.OrientationChangeDeg
+}
+
+func (c StumbleConfig) postImpactWindow() time.Duration {
+	if c.PostImpactWindowMs <= 0 {
+		return defaultPostImpactWindowMs * time.Millisecond
+	}
+	return time.Duration(c.PostImpactWindowMs) * time.Millisecond
+}
+
+func (c StumbleConfig) cooldown() time.Duration {
+	if c.CooldownMs <= 0 {
+		return defaultCooldownMs * time.Millisecond
+	}
+	return time.Duration(c.CooldownMs) * time.Millisecond
+}
+
+func (c StumbleConfig) maxEvents() int {
+	if c.MaxEvents <= 0 {
+		return defaultMaxEvents
+	}
+	return c.MaxEvents
+}
+
+// StumbleEvent records a single detected stumble/fall, including enough context
+// (peak magnitude, pre/post orientation) to reconstruct roughly what happened.
+type StumbleEvent struct {
+	Time          time.Time `json:"time"`
+	PeakMagnitude float64   `json:"peak_magnitude_g"`
+	PrePitch      float64   `json:"pre_pitch"`
+	PreRoll       float64   `json:"pre_roll"`
+	PostPitch     float64   `json:"post_pitch"`
+	PostRoll      float64   `json:"post_roll"`
+}
+
+// fallDetectorState walks through the free-fall -> impact -> orientation-change state
+// machine that makes up a single candidate stumble.
+type fallDetectorState int
+
+const (
+	fdIdle fallDetectorState = iota
+	fdFreeFalling
+	fdAwaitingImpact
+	fdAwaitingOrientation
+)
+
+// stumbleAnalyzer implements stumble/fall detection: a sustained dip below a
+// free-fall threshold, followed by an impact peak, followed by a meaningful
+// orientation change, fires a StumbleEvent.
+type stumbleAnalyzer struct {
+	name   string
+	logger golog.Logger
+	cfg    StumbleConfig
+
+	state fallDetectorState
+
+	freeFallStart time.Time
+	deadline      time.Time
+
+	prePitch, preRoll float64
+	impactPeak        float64
+	impactTime        time.Time
+
+	lastEventTime time.Time
+
+	eventsMu sync.Mutex
+	events   []StumbleEvent
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newStumbleAnalyzer(name string, attributes map[string]interface{}, logger golog.Logger) (Analyzer, error) {
+	var cfg StumbleConfig
+	if err := decodeAttributes(attributes, &cfg); err != nil {
+		return nil, err
+	}
+	return &stumbleAnalyzer{name: name, logger: logger, cfg: cfg}, nil
+}
+
+func (a *stumbleAnalyzer) Name() string {
+	return a.name
+}
+
+func (a *stumbleAnalyzer) Start(ctx context.Context, samples <-chan Sample) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	a.cancel = cancel
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case s, ok := <-samples:
+				if !ok {
+					return
+				}
+				a.sample(s)
+			}
+		}
+	}()
+	return nil
+}
+
+func (a *stumbleAnalyzer) Stop(ctx context.Context) error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+	return nil
+}
+
+func (a *stumbleAnalyzer) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	switch cmd["command"] {
+	case "get_events":
+		since := time.Time{}
+		if sinceMs, ok := cmd["since"].(float64); ok {
+			since = time.UnixMilli(int64(sinceMs))
+		}
+		return map[string]interface{}{"events": a.getEvents(since)}, nil
+	case "clear_events":
+		a.clearEvents()
+		return map[string]interface{}{}, nil
+	default:
+		return nil, errors.Errorf("unknown command string %v", cmd["command"])
+	}
+}
+
+func (a *stumbleAnalyzer) recordEvent(evt StumbleEvent) {
+	a.eventsMu.Lock()
+	defer a.eventsMu.Unlock()
+	a.events = append(a.events, evt)
+	if over := len(a.events) - a.cfg.maxEvents(); over > 0 {
+		a.events = a.events[over:]
+	}
+}
+
+func (a *stumbleAnalyzer) getEvents(since time.Time) []StumbleEvent {
+	a.eventsMu.Lock()
+	defer a.eventsMu.Unlock()
+	out := make([]StumbleEvent, 0, len(a.events))
+	for _, evt := range a.events {
+		if evt.Time.After(since) {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+func (a *stumbleAnalyzer) clearEvents() {
+	a.eventsMu.Lock()
+	defer a.eventsMu.Unlock()
+	a.events = nil
+}
+
+// svmG returns the sum-vector-magnitude of a linear acceleration reading, in g's.
+func svmG(x, y, z float64) float64 {
+	return math.Sqrt(x*x+y*y+z*z) / standardGravity
+}
+
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+func (a *stumbleAnalyzer) sample(s Sample) {
+	ea := s.Orientation.EulerAngles()
+	a.process(s.Time, s.Accel.X, s.Accel.Y, s.Accel.Z, ea.Pitch, ea.Roll)
+}
+
+// process runs one reading through the free-fall/impact/orientation-change state
+// machine. It takes accel and pitch/roll (radians) directly rather than a Sample so the
+// state machine itself can be unit tested without a spatialmath.Orientation.
+func (a *stumbleAnalyzer) process(t time.Time, accelX, accelY, accelZ, pitch, roll float64) {
+	svm := svmG(accelX, accelY, accelZ)
+
+	switch a.state {
+	case fdIdle:
+		if svm < a.cfg.freeFallThresholdG() {
+			a.freeFallStart = t
+			a.state = fdFreeFalling
+		}
+
+	case fdFreeFalling:
+		if svm >= a.cfg.freeFallThresholdG() {
+			if t.Sub(a.freeFallStart) >= a.cfg.freeFallMin() {
+				a.prePitch = pitch
+				a.preRoll = roll
+				a.impactPeak = svm
+				a.deadline = t.Add(a.cfg.impactWindow())
+				a.state = fdAwaitingImpact
+			} else {
+				a.state = fdIdle
+			}
+		}
+
+	case fdAwaitingImpact:
+		if svm > a.cfg.impactThresholdG() {
+			a.impactPeak = svm
+			a.impactTime = t
+			a.deadline = t.Add(a.cfg.postImpactWindow())
+			a.state = fdAwaitingOrientation
+			break
+		}
+		if t.After(a.deadline) {
+			a.state = fdIdle
+		}
+
+	case fdAwaitingOrientation:
+		if svm > a.impactPeak {
+			a.impactPeak = svm
+		}
+		pitchDelta := math.Abs(radToDeg(pitch) - radToDeg(a.prePitch))
+		rollDelta := math.Abs(radToDeg(roll) - radToDeg(a.preRoll))
+		if pitchDelta >= a.cfg.orientationChangeDeg() || rollDelta >= a.cfg.orientationChangeDeg() {
+			if a.lastEventTime.IsZero() || t.Sub(a.lastEventTime) >= a.cfg.cooldown() {
+				a.recordEvent(StumbleEvent{
+					Time:          a.impactTime,
+					PeakMagnitude: a.impactPeak,
+					PrePitch:      a.prePitch,
+					PreRoll:       a.preRoll,
+					PostPitch:     pitch,
+					PostRoll:      roll,
+				})
+				a.lastEventTime = t
+			}
+			a.state = fdIdle
+		} else if t.After(a.deadline) {
+			a.state = fdIdle
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestStumbleAnalyzer() *stumbleAnalyzer {
+	// logger is left nil: process() never logs, so no test logger is needed here.
+	return &stumbleAnalyzer{name: "test", cfg: StumbleConfig{}}
+}
+
+func TestStumbleDetectorFiresOnFallThenImpactThenOrientationChange(t *testing.T) {
+	a := newTestStumbleAnalyzer()
+	base := time.Unix(0, 0)
+
+	// Resting: well above the free-fall threshold, should stay idle.
+	a.process(base, 0, 0, 9.8, 0, 0)
+	if a.state != fdIdle {
+		t.Fatalf("expected idle after a resting sample, got state %d", a.state)
+	}
+
+	// Free-fall: SVM near zero, sustained past the default 50ms minimum.
+	for i := 0; i <= 6; i++ {
+		a.process(base.Add(time.Duration(i)*10*time.Millisecond), 0, 0, 0.1, 0, 0)
+	}
+	if a.state != fdFreeFalling {
+		t.Fatalf("expected still free-falling mid-window, got state %d", a.state)
+	}
+
+	// Free-fall ends (back above threshold) after >= 50ms: confirms free-fall, awaits impact.
+	freeFallEnd := base.Add(70 * time.Millisecond)
+	a.process(freeFallEnd, 0, 0, 9.8, 0, 0)
+	if a.state != fdAwaitingImpact {
+		t.Fatalf("expected to be awaiting impact, got state %d", a.state)
+	}
+
+	// Impact: SVM spikes well above the default 2g threshold, within the impact window.
+	impactTime := freeFallEnd.Add(100 * time.Millisecond)
+	a.process(impactTime, 0, 0, 25, 0, 0)
+	if a.state != fdAwaitingOrientation {
+		t.Fatalf("expected to be awaiting orientation change, got state %d", a.state)
+	}
+
+	// Orientation settles at a pitch well past the default 30deg threshold.
+	settleTime := impactTime.Add(100 * time.Millisecond)
+	a.process(settleTime, 0, 0, 9.8, 1.2, 0)
+	if a.state != fdIdle {
+		t.Fatalf("expected to return to idle after firing, got state %d", a.state)
+	}
+
+	events := a.getEvents(time.Time{})
+	if len(events) != 1 {
+		t.Fatalf("expected exactly one event, got %d", len(events))
+	}
+	if events[0].PeakMagnitude <= a.cfg.impactThresholdG() {
+		t.Errorf("expected peak magnitude above the impact threshold, got %f", events[0].PeakMagnitude)
+	}
+}
+
+func TestStumbleDetectorIgnoresBriefDip(t *testing.T) {
+	a := newTestStumbleAnalyzer()
+	base := time.Unix(0, 0)
+
+	a.process(base, 0, 0, 0.1, 0, 0)
+	if a.state != fdFreeFalling {
+		t.Fatalf("expected free-falling after the first low-SVM sample, got state %d", a.state)
+	}
+
+	// Back above threshold after only 10ms, well under the 50ms minimum: should reset.
+	a.process(base.Add(10*time.Millisecond), 0, 0, 9.8, 0, 0)
+	if a.state != fdIdle {
+		t.Fatalf("expected idle after a too-brief dip, got state %d", a.state)
+	}
+	if len(a.getEvents(time.Time{})) != 0 {
+		t.Fatalf("expected no events from a too-brief dip")
+	}
+}
+
+func TestStumbleDetectorNoEventWithoutOrientationChange(t *testing.T) {
+	a := newTestStumbleAnalyzer()
+	base := time.Unix(0, 0)
+
+	a.process(base, 0, 0, 0.1, 0, 0)
+	freeFallEnd := base.Add(70 * time.Millisecond)
+	a.process(freeFallEnd, 0, 0, 9.8, 0, 0)
+	impactTime := freeFallEnd.Add(100 * time.Millisecond)
+	a.process(impactTime, 0, 0, 25, 0, 0)
+
+	// Orientation barely moves, and we wait past the post-impact window without a change.
+	timeout := impactTime.Add(a.cfg.postImpactWindow() + 10*time.Millisecond)
+	a.process(timeout, 0, 0, 9.8, 0.01, 0)
+	if a.state != fdIdle {
+		t.Fatalf("expected idle after the post-impact window times out, got state %d", a.state)
+	}
+	if len(a.getEvents(time.Time{})) != 0 {
+		t.Fatalf("expected no events when orientation never changes enough")
+	}
+}
@@ -3,15 +3,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/edaniels/golog"
+	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
-	"github.com/RobinUS2/golang-moving-average"
-
+	"github.com/viam-labs/stumbler/analyzers"
 	"github.com/viam-labs/stumbler/mpu9250"
 
 	"go.viam.com/rdk/components/generic"
@@ -65,9 +67,53 @@ func mainWithArgs(ctx context.Context, args []string, logger golog.Logger) error
 	return nil
 }
 
-
+// Config selects the IMU to read from and which analyzers to run against its samples.
 type Config struct {
 	IMU string `json:"imu"`
+
+	// Analyzers selects which analyzer instances are enabled and their per-instance parameters.
+	Analyzers []analyzers.InstanceConfig `json:"analyzers"`
+
+	// SampleHz is how fast the read loop pulls from the IMU.
+	SampleHz float64 `json:"sample_hz"`
+	// MaxSamples caps the read loop's lifetime iteration count; 0 means unbounded.
+	MaxSamples int `json:"max_samples"`
+	// ComplementaryAlpha weights gyro integration against the accel-derived angle in the
+	// fused orientation estimate; higher trusts the gyro more.
+	ComplementaryAlpha float64 `json:"complementary_alpha"`
+	// Calibration configures gyro-bias removal, either a fixed bias or a startup collection.
+	Calibration CalibrationConfig `json:"calibration"`
+}
+
+// CalibrationConfig controls how the gyro bias offset is derived.
+type CalibrationConfig struct {
+	// Seconds is how long to collect stationary samples for at startup; 0 skips startup calibration.
+	Seconds float64 `json:"seconds"`
+	// GyroBiasX/Y/Z are used as-is if Seconds is 0; otherwise they apply only until startup
+	// calibration replaces them with the measured average (or if that calibration fails).
+	GyroBiasX float64 `json:"gyro_bias_x"`
+	GyroBiasY float64 `json:"gyro_bias_y"`
+	GyroBiasZ float64 `json:"gyro_bias_z"`
+}
+
+const (
+	defaultSampleHz           = 100.0
+	defaultComplementaryAlpha = 0.98
+	defaultCalibrationSeconds = 2.0
+)
+
+func (cfg *Config) sampleHz() float64 {
+	if cfg.SampleHz <= 0 {
+		return defaultSampleHz
+	}
+	return cfg.SampleHz
+}
+
+func (cfg *Config) complementaryAlpha() float64 {
+	if cfg.ComplementaryAlpha <= 0 {
+		return defaultComplementaryAlpha
+	}
+	return cfg.ComplementaryAlpha
 }
 
 // Validate ensures all parts of the config are valid.
@@ -80,22 +126,48 @@ func (cfg *Config) Validate(path string) ([]string, error) {
 
 func newStumbler(ctx context.Context, deps resource.Dependencies, conf resource.Config, logger *zap.SugaredLogger) (resource.Resource, error) {
 	newStumbler := &stumbler{
-		Named: conf.ResourceName().AsNamed(),
+		Named:  conf.ResourceName().AsNamed(),
 		logger: logger,
+		group:  analyzers.NewGroup(logger),
 	}
 
 	return newStumbler, newStumbler.Reconfigure(ctx, deps, conf)
 }
 
-// counter is the representation of this model. It holds only a "total" count.
+// sampleLoopConfig snapshots everything the read loop's hot path needs from a Config,
+// so it can load it once per iteration via an atomic pointer swap instead of taking
+// s.mu every sample and contending with Reconfigure and calibrate().
+type sampleLoopConfig struct {
+	imu        movementsensor.MovementSensor
+	period     time.Duration
+	alpha      float64
+	maxSamples int
+	bias       r3.Vector
+}
+
+// stumbler owns a single IMU read loop and fans the resulting samples out to whatever
+// analyzers are currently configured; the analyzers do the actual reporting, stumble
+// detection, and sample recording.
 type stumbler struct {
 	resource.Named
 	logger golog.Logger
 
-	mu sync.RWMutex
-	imu movementsensor.MovementSensor
+	mu         sync.RWMutex
+	imu        movementsensor.MovementSensor
+	conf       *Config
+	gyroBias   r3.Vector
 	cancelFunc func()
-	wg sync.WaitGroup
+	wg         sync.WaitGroup
+
+	loopConfig atomic.Pointer[sampleLoopConfig]
+
+	group *analyzers.Group
+
+	orientationMu  sync.RWMutex
+	fusedPitch     float64
+	fusedRoll      float64
+	fusedYaw       float64
+	lastFusionTime time.Time
 }
 
 func (s *stumbler) Reconfigure(ctx context.Context, deps resource.Dependencies, conf resource.Config) error {
@@ -109,12 +181,120 @@ func (s *stumbler) Reconfigure(ctx context.Context, deps resource.Dependencies,
 		return err
 	}
 	s.mu.Lock()
+	// Only seed gyroBias from config the first time we see it, or when the calibration
+	// config itself changes. Otherwise a later Reconfigure (e.g. just to add an
+	// analyzer) would stomp a bias obtained via calibrate() back to the static config value.
+	if s.conf == nil || s.conf.Calibration != newConf.Calibration {
+		s.gyroBias = r3.Vector{X: newConf.Calibration.GyroBiasX, Y: newConf.Calibration.GyroBiasY, Z: newConf.Calibration.GyroBiasZ}
+	}
 	s.imu = imu
+	s.conf = newConf
+	s.refreshLoopConfigLocked()
 	s.mu.Unlock()
+
+	if err := s.group.Reconfigure(ctx, newConf.Analyzers); err != nil {
+		return err
+	}
+
 	return s.startBackground()
 }
 
+// calibrate collects `seconds` of gyro samples and sets their average as the gyro bias,
+// subtracted from every subsequent reading.
+func (s *stumbler) calibrate(ctx context.Context, seconds float64) (r3.Vector, error) {
+	s.mu.RLock()
+	imu := s.imu
+	s.mu.RUnlock()
+	if imu == nil {
+		return r3.Vector{}, errors.New("imu not ready")
+	}
+
+	var sum r3.Vector
+	var n int
+	deadline := time.Now().Add(time.Duration(seconds * float64(time.Second)))
+	for time.Now().Before(deadline) {
+		gyro, err := imu.AngularVelocity(ctx, nil)
+		if err != nil {
+			return r3.Vector{}, err
+		}
+		sum.X += gyro.X
+		sum.Y += gyro.Y
+		sum.Z += gyro.Z
+		n++
+		if !utils.SelectContextOrWait(ctx, time.Millisecond*10) {
+			break
+		}
+	}
+	if n == 0 {
+		return r3.Vector{}, errors.New("no samples collected during calibration")
+	}
+
+	bias := r3.Vector{X: sum.X / float64(n), Y: sum.Y / float64(n), Z: sum.Z / float64(n)}
+	s.mu.Lock()
+	s.gyroBias = bias
+	s.refreshLoopConfigLocked()
+	s.mu.Unlock()
+	return bias, nil
+}
+
+// refreshLoopConfigLocked rebuilds the read loop's atomic config snapshot from the
+// current s.conf/s.imu/s.gyroBias. Callers must hold s.mu.
+func (s *stumbler) refreshLoopConfigLocked() {
+	s.loopConfig.Store(&sampleLoopConfig{
+		imu:        s.imu,
+		period:     time.Duration(float64(time.Second) / s.conf.sampleHz()),
+		alpha:      s.conf.complementaryAlpha(),
+		maxSamples: s.conf.MaxSamples,
+		bias:       s.gyroBias,
+	})
+}
+
+// accelPitch and accelRoll estimate pitch/roll (in radians) from linear acceleration alone,
+// assuming the sensor is roughly stationary so gravity dominates the reading.
+func accelPitch(accel r3.Vector) float64 {
+	return math.Atan2(-accel.X, math.Sqrt(accel.Y*accel.Y+accel.Z*accel.Z))
+}
+
+func accelRoll(accel r3.Vector) float64 {
+	return math.Atan2(accel.Y, accel.Z)
+}
+
+// fuseOrientation folds one accel+gyro reading into the running complementary-filter
+// estimate of pitch/roll/yaw.
+func (s *stumbler) fuseOrientation(now time.Time, accel, gyro r3.Vector, alpha float64) {
+	s.orientationMu.Lock()
+	defer s.orientationMu.Unlock()
+
+	pitchAcc := accelPitch(accel)
+	rollAcc := accelRoll(accel)
+
+	if s.lastFusionTime.IsZero() {
+		s.fusedPitch = pitchAcc
+		s.fusedRoll = rollAcc
+		s.lastFusionTime = now
+		return
+	}
+
+	dt := now.Sub(s.lastFusionTime).Seconds()
+	s.lastFusionTime = now
+
+	s.fusedPitch = alpha*(s.fusedPitch+gyro.X*dt) + (1-alpha)*pitchAcc
+	s.fusedRoll = alpha*(s.fusedRoll+gyro.Y*dt) + (1-alpha)*rollAcc
+	s.fusedYaw += gyro.Z * dt
+}
+
+func (s *stumbler) getFusedOrientation() (pitch, roll, yaw float64) {
+	s.orientationMu.RLock()
+	defer s.orientationMu.RUnlock()
+	return s.fusedPitch, s.fusedRoll, s.fusedYaw
+}
+
 func (s *stumbler) DoCommand(ctx context.Context, req map[string]interface{}) (map[string]interface{}, error) {
+	// A request naming an "analyzer" is routed straight to that instance.
+	if name, ok := req["analyzer"].(string); ok {
+		return s.group.DoCommand(ctx, name, req)
+	}
+
 	// We look for a map key called "command"
 	cmd, ok := req["command"]
 	if !ok {
@@ -126,6 +306,23 @@ func (s *stumbler) DoCommand(ctx context.Context, req map[string]interface{}) (m
 		return map[string]interface{}{}, nil
 	}
 
+	if cmd == "calibrate" {
+		seconds, _ := req["seconds"].(float64)
+		if seconds <= 0 {
+			seconds = defaultCalibrationSeconds
+		}
+		bias, err := s.calibrate(ctx, seconds)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"gyro_bias": bias}, nil
+	}
+
+	if cmd == "orientation" {
+		pitch, roll, yaw := s.getFusedOrientation()
+		return map[string]interface{}{"pitch": pitch, "roll": roll, "yaw": yaw}, nil
+	}
+
 	// The command must've been something else.
 	return nil, fmt.Errorf("unknown command string %s", cmd)
 }
@@ -133,73 +330,96 @@ func (s *stumbler) DoCommand(ctx context.Context, req map[string]interface{}) (m
 func (s *stumbler) Close(ctx context.Context) error {
 	s.logger.Info("SMURF CLOSING STUMBLER")
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.cancelFunc != nil {
-		s.cancelFunc()
+	cancelFunc := s.cancelFunc
+	s.mu.Unlock()
+	if cancelFunc != nil {
+		cancelFunc()
 	}
+	// wg.Wait() must happen without s.mu held: the read loop's hot path reads its config
+	// via sampleLoopConfig, but the startup calibration in the same goroutine calls
+	// calibrate(), which takes s.mu, so holding the lock here could deadlock.
 	s.wg.Wait()
-	return nil
+	return s.group.StopAll(ctx)
 }
 
+// startBackground starts the IMU read loop the first time it's called; subsequent
+// Reconfigure calls only diff the analyzer set via s.group.Reconfigure, so a slow or
+// changing analyzer config never tears down the read loop itself.
 func (s *stumbler) startBackground() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.cancelFunc != nil {
+		return nil
+	}
+	calibrateSeconds := s.conf.Calibration.Seconds
+
 	s.wg.Add(1)
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	s.cancelFunc = cancelFunc
-	go func(imu movementsensor.MovementSensor){
+	go func() {
 		defer s.wg.Done()
-		startTime := time.Now()
-		var iterations int
 
-		X := movingaverage.New(50)
-		Y := movingaverage.New(50)
-		Z := movingaverage.New(50)
+		if calibrateSeconds > 0 {
+			if _, err := s.calibrate(ctx, calibrateSeconds); err != nil {
+				s.logger.Errorw("startup calibration failed", "error", err)
+			}
+		}
 
-		RX := movingaverage.New(50)
-		RY := movingaverage.New(50)
-		RZ := movingaverage.New(50)
+		startTime := time.Now()
+		var iterations int
 
 		for {
-			if ctx.Err() != nil || iterations >= 10000 {
+			if ctx.Err() != nil {
 				break
 			}
-			// if !utils.SelectContextOrWait(ctx, time.Millisecond*1000) {
-			// 	break
-			// }
+
+			loopCfg := s.loopConfig.Load()
+			imu := loopCfg.imu
+			period := loopCfg.period
+			alpha := loopCfg.alpha
+			maxSamples := loopCfg.maxSamples
+			bias := loopCfg.bias
 
 			accel, err := imu.LinearAcceleration(ctx, nil)
 			if err != nil {
 				s.logger.Error(err)
 			}
-			X.Add(accel.X)
-			Y.Add(accel.Y)
-			Z.Add(accel.Z)
 
 			gyro, err := imu.AngularVelocity(ctx, nil)
 			if err != nil {
 				s.logger.Error(err)
 			}
-			RX.Add(gyro.X)
-			RY.Add(gyro.Y)
-			RZ.Add(gyro.Z)
+			gyro.X -= bias.X
+			gyro.Y -= bias.Y
+			gyro.Z -= bias.Z
+
+			pose, err := imu.Orientation(ctx, nil)
+			if err != nil {
+				s.logger.Error(err)
+			}
+
+			now := time.Now()
+			s.fuseOrientation(now, accel, gyro, alpha)
+
+			s.group.Dispatch(analyzers.Sample{
+				Time:        now,
+				Accel:       accel,
+				Gyro:        gyro,
+				Orientation: pose,
+			})
 
-			//s.logger.Infow("SMURF", "Acceleration", accel, "Gyro", gyro)
 			iterations++
+			if maxSamples > 0 && iterations >= maxSamples {
+				break
+			}
+
+			if !utils.SelectContextOrWait(ctx, period) {
+				break
+			}
 		}
 		runtime := time.Now().Sub(startTime)
 		updateFreq := float64(iterations) / runtime.Seconds()
 		s.logger.Infow("Run Finished", "Runtime", runtime, "Iterations", iterations, "Frequency", updateFreq)
-		s.logger.Infow("Averages", "X", X.Avg(), "Y", Y.Avg(), "Z", Z.Avg(), "RX", RX.Avg(), "RY", RY.Avg(), "RZ", RZ.Avg())
-
-
-		pose, err := imu.Orientation(ctx, nil)
-		if err != nil {
-			s.logger.Error(err)
-		}
-		s.logger.Infow("Orientation", "pitch", pose.EulerAngles().Pitch, "roll", pose.EulerAngles().Roll, "yaw", pose.EulerAngles().Yaw)
-
-
-	}(s.imu)
+	}()
 	return nil
 }